@@ -6,8 +6,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"reflect"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -18,21 +21,111 @@ import (
 	jsprocessor "github.com/elastic/beats/libbeat/processors/script/javascript/module/processor"
 )
 
+// Supported parse_grace modes, modeled on mongoimport's --parseGrace flag.
+const (
+	parseGraceAutoCast  = "autoCast"
+	parseGraceSkipField = "skip_field"
+	parseGraceSkipRow   = "skip_row"
+	parseGraceStop      = "stop"
+)
+
+// errSkipRow is a sentinel error used internally to signal that parse_grace
+// "skip_row" decided the whole event must be dropped.
+var errSkipRow = errors.New("parse_grace: skip_row")
+
+// ErrFieldCountMismatch is returned (wrapped via errors.Wrapf) when a CSV
+// record does not have the expected number of columns, per fields_per_record
+// or the configured header. It is exported as a distinct sentinel so
+// downstream code can route malformed rows to a dead-letter output instead
+// of treating them like any other decode failure.
+var ErrFieldCountMismatch = errors.New("csv record has the wrong number of fields")
+
 type mydecodeCSVFields struct {
 	csvConfig
 	fields    map[string]string
 	separator rune
 	headers   map[string]csvHeader
+	types     map[string]string
+
+	// headerLineCache holds the raw header line read from a headers.file
+	// or log.file.path, keyed by "path#offset", so the hot path does not
+	// re-open and re-scan the file on every event.
+	headerLineCache sync.Map
+	// headerFieldsCache memoizes the csv.Reader.Read() of a header line,
+	// keyed by the line itself.
+	headerFieldsCache sync.Map
+
+	// structType is the registered target_struct type, when configured.
+	structType reflect.Type
+}
+
+// TypeUnmarshaller lets a struct field registered via RegisterCSVStruct take
+// over parsing its own value (e.g. net.IP, time.Time, url.URL), the way
+// gocarina/gocsv's TypeUnmarshaller works.
+type TypeUnmarshaller interface {
+	UnmarshalCSV(value string) error
+}
+
+var (
+	structRegistryMu sync.RWMutex
+	structRegistry   = make(map[string]reflect.Type)
+)
+
+// RegisterCSVStruct registers a Go struct (or pointer to one) under name so
+// config can reference it as target_struct. Exported fields are decoded
+// from the CSV header-to-value map using `csv:"column_name"` tags in the
+// style of gocarina/gocsv; `csv:"-"` skips a field, an empty tag falls back
+// to the field name, and a field implementing TypeUnmarshaller takes over
+// its own parsing. Returns an error instead of registering a nil or
+// non-struct prototype, since buildStruct would otherwise panic on every
+// event decoded against it.
+func RegisterCSVStruct(name string, prototype interface{}) error {
+	if prototype == nil {
+		return errors.Errorf("RegisterCSVStruct %s: prototype must not be nil", name)
+	}
+	typ := reflect.TypeOf(prototype)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return errors.Errorf("RegisterCSVStruct %s: prototype must be a struct or pointer to one, got %s", name, typ.Kind())
+	}
+	structRegistryMu.Lock()
+	defer structRegistryMu.Unlock()
+	structRegistry[name] = typ
+	return nil
+}
+
+func lookupCSVStruct(name string) (reflect.Type, bool) {
+	structRegistryMu.RLock()
+	defer structRegistryMu.RUnlock()
+	typ, ok := structRegistry[name]
+	return typ, ok
+}
+
+// headerLineCacheEntry is the value stored in headerLineCache.
+type headerLineCacheEntry struct {
+	line     string
+	modTime  time.Time
+	size     int64
+	cachedAt time.Time
 }
 
 type csvConfig struct {
-	Fields           common.MapStr `config:"fields"`
-	IgnoreMissing    bool          `config:"ignore_missing"`
-	TrimLeadingSpace bool          `config:"trim_leading_space"`
-	OverwriteKeys    bool          `config:"overwrite_keys"`
-	FailOnError      bool          `config:"fail_on_error"`
-	Separator        string        `config:"separator"`
-	Headers          common.MapStr `config:"headers`
+	Fields               common.MapStr `config:"fields"`
+	IgnoreMissing        bool          `config:"ignore_missing"`
+	TrimLeadingSpace     bool          `config:"trim_leading_space"`
+	OverwriteKeys        bool          `config:"overwrite_keys"`
+	FailOnError          bool          `config:"fail_on_error"`
+	Separator            string        `config:"separator"`
+	Headers              common.MapStr `config:"headers"`
+	Types                common.MapStr `config:"types"`
+	ParseGrace           string        `config:"parse_grace"`
+	MultiRecord          bool          `config:"multi_record"`
+	ExpandEventFromField string        `config:"expand_event_from_field"`
+	HeaderCacheTTL       time.Duration `config:"header_cache_ttl"`
+	FieldsPerRecord      int           `config:"fields_per_record"`
+	TargetStruct         string        `config:"target_struct"`
 }
 
 type csvHeader struct {
@@ -50,6 +143,7 @@ var (
 	defaultCSVConfig = csvConfig{
 		Separator:   ",",
 		FailOnError: true,
+		ParseGrace:  parseGraceStop,
 	}
 
 	errFieldAlreadySet = errors.New("field already has a value")
@@ -60,9 +154,25 @@ func init() {
 		checks.ConfigChecked(MyNewDecodeCSVField,
 			checks.RequireFields("fields"),
 			checks.AllowedFields("fields", "ignore_missing", "overwrite_keys", "separator", "trim_leading_space", "overwrite_keys", "fail_on_error", "when",
-				"headers", "file", "header", "offset", "path")))
+				"headers", "file", "header", "offset", "path", "types", "parse_grace", "multi_record", "expand_event_from_field", "header_cache_ttl", "fields_per_record", "target_struct")))
 
 	jsprocessor.RegisterPlugin("MyDecodeCSVField", MyNewDecodeCSVField)
+
+	// Sibling log-format decoders, sharing the fields/ignore_missing/
+	// overwrite_keys/fail_on_error config surface with mydecode_csv_fields.
+	processors.RegisterPlugin("mydecode_ltsv_fields",
+		checks.ConfigChecked(MyNewDecodeLTSVField,
+			checks.RequireFields("fields"),
+			checks.AllowedFields("fields", "ignore_missing", "overwrite_keys", "fail_on_error", "when")))
+
+	jsprocessor.RegisterPlugin("MyDecodeLTSVField", MyNewDecodeLTSVField)
+
+	processors.RegisterPlugin("mydecode_regex_fields",
+		checks.ConfigChecked(MyNewDecodeRegexField,
+			checks.RequireFields("fields", "pattern"),
+			checks.AllowedFields("fields", "ignore_missing", "overwrite_keys", "fail_on_error", "when", "pattern")))
+
+	jsprocessor.RegisterPlugin("MyDecodeRegexField", MyNewDecodeRegexField)
 }
 
 // NewDecodeCSVField construct a new decode_csv_field processor.
@@ -76,6 +186,11 @@ func MyNewDecodeCSVField(c *common.Config) (processors.Processor, error) {
 	if len(config.Fields) == 0 {
 		return nil, errors.New("no fields to decode configured")
 	}
+	switch config.ParseGrace {
+	case parseGraceAutoCast, parseGraceSkipField, parseGraceSkipRow, parseGraceStop:
+	default:
+		return nil, errors.Errorf("parse_grace must be one of 'autoCast', 'skip_field', 'skip_row' or 'stop', got '%s'", config.ParseGrace)
+	}
 	f := &mydecodeCSVFields{csvConfig: config}
 	// Set separator as rune
 	switch runes := []rune(config.Separator); len(runes) {
@@ -95,6 +210,15 @@ func MyNewDecodeCSVField(c *common.Config) (processors.Processor, error) {
 		}
 		f.fields[src] = dst
 	}
+	// Set types as header name -> type name
+	f.types = make(map[string]string, len(config.Types))
+	for name, typeIf := range config.Types.Flatten() {
+		typ, ok := typeIf.(string)
+		if !ok {
+			return nil, errors.Errorf("bad type for field %s: type must be string, not %T (got %v)", name, typeIf, typeIf)
+		}
+		f.types[name] = typ
+	}
 	// Set headers as string -> csvHeader
 	f.headers = make(map[string]csvHeader, len(config.Headers))
 	for src, dstIf := range config.Headers {
@@ -120,47 +244,94 @@ func MyNewDecodeCSVField(c *common.Config) (processors.Processor, error) {
 		}
 		f.headers[src] = toHeader
 	}
+	// Set the target_struct type, if configured
+	if config.TargetStruct != "" {
+		typ, ok := lookupCSVStruct(config.TargetStruct)
+		if !ok {
+			return nil, errors.Errorf("target_struct %s is not registered, call RegisterCSVStruct first", config.TargetStruct)
+		}
+		f.structType = typ
+	}
+	// expand_event_from_field cannot be honored: processors.Processor only
+	// exposes Run(event) (*beat.Event, error), a one-event-in-one-event-out
+	// contract, and this package has no publisher/client handle to emit
+	// extra events through instead. Rather than silently dropping every row
+	// but the first, refuse to start so operators see the problem at
+	// config-load time instead of losing data at runtime.
+	if config.ExpandEventFromField != "" {
+		return nil, errors.Errorf("expand_event_from_field is not supported: Run's processors.Processor signature can only return a single event, so fanning %s out into multiple events would silently drop every row but the first; store multi_record's row array at a destination field instead and expand it downstream", config.ExpandEventFromField)
+	}
 	return f, nil
 }
 
-// Run applies the mydecode_csv_field processor to an event.
+// cloneEvent makes an independent copy of event (Fields and Meta), which
+// the Run methods return in place of the original when fail_on_error is set
+// and decoding fails.
+func cloneEvent(event *beat.Event) *beat.Event {
+	clone := *event
+	clone.Fields = event.Fields.Clone()
+	clone.Meta = event.Meta.Clone()
+	return &clone
+}
+
+// Run applies the mydecode_csv_field processor to an event. multi_record
+// still reads every CSV row and stores the resulting array at dest (see
+// decodeMultiRecordField); fanning those rows out into separate events is
+// deliberately not supported here, since processors.Processor's Run can
+// only ever return one event (see MyNewDecodeCSVField's rejection of
+// expand_event_from_field).
 func (f *mydecodeCSVFields) Run(event *beat.Event) (*beat.Event, error) {
-	saved := *event
+	saved := event
 	if f.FailOnError {
-		saved.Fields = event.Fields.Clone()
-		saved.Meta = event.Meta.Clone()
+		saved = cloneEvent(event)
 	}
 	for src, dest := range f.fields {
-		if err := f.decodeCSVField(src, dest, event); err != nil && f.FailOnError {
-			return &saved, err
+		if err := f.decodeCSVField(src, dest, event); err != nil {
+			if errors.Cause(err) == errSkipRow {
+				if f.FailOnError {
+					return saved, err
+				}
+				return nil, nil
+			}
+			if f.FailOnError {
+				return saved, err
+			}
 		}
 	}
 	return event, nil
 }
 
 func (f *mydecodeCSVFields) decodeCSVField(src, dest string, event *beat.Event) error {
-	data, err := event.GetValue(src)
-	if err != nil {
-		if f.IgnoreMissing && errors.Cause(err) == common.ErrKeyNotFound {
-			return nil
-		}
-		return errors.Wrapf(err, "could not fetch value for field %s", src)
+	text, err := f.fetchSourceText(src, event)
+	if err != nil || text == "" {
+		return err
 	}
 
-	text, ok := data.(string)
-	if !ok {
-		return errors.Errorf("field %s is not of string type", src)
+	if f.MultiRecord {
+		return f.decodeMultiRecordField(src, dest, text, event)
 	}
 
-	reader := csv.NewReader(strings.NewReader(text))
-	reader.Comma = f.separator
-	reader.TrimLeadingSpace = f.TrimLeadingSpace
-	// LazyQuotes makes the parser more tolerant to bad string formatting.
-	reader.LazyQuotes = true
+	/*********** mon code *********************/
+	//check if default decode_csv_fields or custom
+	_, headered := f.Headers[src]
 
+	var headcsv []string
+	if headered {
+		var skip bool
+		headcsv, skip, err = f.headerColumns(src, text, event)
+		if err != nil {
+			return err
+		}
+		if skip {
+			return nil
+		}
+	}
+
+	reader := f.newReader(text)
+	f.applyFieldsPerRecord(reader, headcsv)
 	record, err := reader.Read()
 	if err != nil {
-		return errors.Wrapf(err, "error decoding CSV from field %s", src)
+		return wrapCSVReadError(src, err)
 	}
 
 	if src != dest && !f.OverwriteKeys {
@@ -169,22 +340,181 @@ func (f *mydecodeCSVFields) decodeCSVField(src, dest string, event *beat.Event)
 		}
 	}
 
-	/*********** mon code *********************/
-	//check if default decode_csv_fields or custom
-	if _, exist := f.Headers[src]; exist == false {
+	if !headered {
 		if _, err = event.PutValue(dest, record); err != nil {
 			return errors.Wrapf(err, "failed setting field %s", dest)
 		}
 		return nil
 	}
 
-	/*
-		priority:
-		1- header string in .yml
-		2- header in a file conf
-		3- header in the file haverested
-	*/
+	//create json object
+	mymap := make(map[string]string)
+	for i := 0; i < len(headcsv) && i < len(record); i++ {
+		mymap[headcsv[i]] = record[i]
+	}
+
+	result, err := f.decodeRow(mymap)
+	if err != nil {
+		return err
+	}
+
+	//put result in fields dest
+	if _, err = event.PutValue(dest, result); err != nil {
+		return errors.Wrapf(err, "failed setting field %s", dest)
+	}
+	return nil
+}
+
+// decodeRow converts a single header-to-value row, using the registered
+// target_struct when configured and falling back to per-field type
+// conversion (applyTypes) otherwise.
+func (f *mydecodeCSVFields) decodeRow(mymap map[string]string) (map[string]interface{}, error) {
+	if f.structType != nil {
+		return f.buildStruct(mymap)
+	}
+	return f.applyTypes(mymap)
+}
+
+// applyFieldsPerRecord sets reader.FieldsPerRecord from f.FieldsPerRecord
+// when configured, otherwise from the known header length so that a
+// malformed record surfaces as ErrFieldCountMismatch instead of an
+// index-out-of-range panic further down the line.
+func (f *mydecodeCSVFields) applyFieldsPerRecord(reader *csv.Reader, headcsv []string) {
+	switch {
+	case f.FieldsPerRecord != 0:
+		reader.FieldsPerRecord = f.FieldsPerRecord
+	case headcsv != nil:
+		reader.FieldsPerRecord = len(headcsv)
+	}
+}
+
+// wrapCSVReadError turns a csv.Reader field-count mismatch into
+// ErrFieldCountMismatch, leaving other decode errors as-is.
+func wrapCSVReadError(src string, err error) error {
+	if perr, ok := err.(*csv.ParseError); ok && perr.Err == csv.ErrFieldCount {
+		return errors.Wrapf(ErrFieldCountMismatch, "field %s: %s", src, perr)
+	}
+	return errors.Wrapf(err, "error decoding CSV from field %s", src)
+}
+
+// decodeMultiRecordField handles the multi_record mode: every row of text is
+// read via ReadAll (instead of stopping after the first row) and mapped
+// against the header, storing the resulting slice at dest. The rows are not
+// fanned out into separate events.
+func (f *mydecodeCSVFields) decodeMultiRecordField(src, dest, text string, event *beat.Event) error {
+	if src != dest && !f.OverwriteKeys {
+		if _, err := event.GetValue(dest); err == nil {
+			return errors.Errorf("target field %s already has a value. Set the overwrite_keys flag or drop/rename the field first", dest)
+		}
+	}
+
+	rows, err := f.decodeRows(src, text, event)
+	if err != nil {
+		return err
+	}
+
+	if _, err := event.PutValue(dest, rows); err != nil {
+		return errors.Wrapf(err, "failed setting field %s", dest)
+	}
+	return nil
+}
+
+// decodeRows reads every CSV row out of text and, when a header is
+// configured for src, maps each row against it (applying f.types the same
+// way decodeCSVField does). Rows dropped by a skip_row parse_grace are
+// omitted rather than aborting the whole batch. Without a configured header,
+// each row is kept as-is, indexed by column position.
+func (f *mydecodeCSVFields) decodeRows(src, text string, event *beat.Event) ([]map[string]interface{}, error) {
+	_, headered := f.Headers[src]
+
+	var headcsv []string
+	if headered {
+		var skip bool
+		var err error
+		headcsv, skip, err = f.headerColumns(src, text, event)
+		if err != nil {
+			return nil, err
+		}
+		if skip {
+			return nil, nil
+		}
+	}
+
+	reader := f.newReader(text)
+	f.applyFieldsPerRecord(reader, headcsv)
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, wrapCSVReadError(src, err)
+	}
+
+	if !headered {
+		rows := make([]map[string]interface{}, 0, len(records))
+		for _, record := range records {
+			row := make(map[string]interface{}, len(record))
+			for i, v := range record {
+				row[strconv.Itoa(i)] = v
+			}
+			rows = append(rows, row)
+		}
+		return rows, nil
+	}
+
+	rows := make([]map[string]interface{}, 0, len(records))
+	for _, record := range records {
+		mymap := make(map[string]string)
+		for i := 0; i < len(headcsv) && i < len(record); i++ {
+			mymap[headcsv[i]] = record[i]
+		}
+		row, err := f.decodeRow(mymap)
+		if err != nil {
+			if errors.Cause(err) == errSkipRow {
+				continue
+			}
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
 
+// fetchSourceText fetches and type-asserts the string value of src from event.
+func (f *mydecodeCSVFields) fetchSourceText(src string, event *beat.Event) (string, error) {
+	data, err := event.GetValue(src)
+	if err != nil {
+		if f.IgnoreMissing && errors.Cause(err) == common.ErrKeyNotFound {
+			return "", nil
+		}
+		return "", errors.Wrapf(err, "could not fetch value for field %s", src)
+	}
+
+	text, ok := data.(string)
+	if !ok {
+		return "", errors.Errorf("field %s is not of string type", src)
+	}
+	return text, nil
+}
+
+// newReader builds a csv.Reader configured the way this processor expects.
+func (f *mydecodeCSVFields) newReader(text string) *csv.Reader {
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.Comma = f.separator
+	reader.TrimLeadingSpace = f.TrimLeadingSpace
+	// LazyQuotes makes the parser more tolerant to bad string formatting.
+	reader.LazyQuotes = true
+	return reader
+}
+
+/*
+	priority:
+	1- header string in .yml
+	2- header in a file conf
+	3- header in the file haverested
+*/
+
+// headerColumns resolves the header line configured for src (see priority
+// above) and splits it into column names. skip is true when text is itself
+// the header line, in which case the caller has nothing left to decode.
+func (f *mydecodeCSVFields) headerColumns(src, text string, event *beat.Event) (headcsv []string, skip bool, err error) {
 	firstLine := ""
 	head, _ := f.headers[src]
 	//event.PutValue("test", len(f.headers));
@@ -201,61 +531,255 @@ func (f *mydecodeCSVFields) decodeCSVField(src, dest string, event *beat.Event)
 			//get path file and open file
 			path, err := event.GetValue("log.file.path")
 			if err != nil {
-				return errors.Wrapf(err, "mydecode_csv_fields only works with file, could not fetch value for field log.file.path")
+				return nil, false, errors.Wrapf(err, "mydecode_csv_fields only works with file, could not fetch value for field log.file.path")
 			}
 			str = fmt.Sprintf("%v", path)
 		}
 		if head.offset == 0 {
 			head.offset = 1
 		}
-		file, err := os.Open(str)
+		firstLine, err = f.readHeaderLine(str, head.offset)
 		if err != nil {
-			return errors.Wrapf(err, "could not open file : log.file.path")
+			return nil, false, err
 		}
-		defer file.Close()
+	}
+	if text == firstLine {
+		return nil, true, nil
+	}
+	//get header record
+	headcsv, err = f.parseHeaderLine(firstLine)
+	if err != nil {
+		return nil, false, err
+	}
+	return headcsv, false, nil
+}
+
+// readHeaderLine returns the header line found at offset in path, caching it
+// across events so the hot path does not re-open and re-scan the file every
+// time. Without header_cache_ttl, the cache is invalidated by comparing the
+// file's mtime and size (os.Stat) against what was observed when it was
+// cached, so log rotations are picked up. With header_cache_ttl set, the
+// cached line is kept for that long regardless of file changes, trading
+// rotation-awareness for fewer stat calls.
+func (f *mydecodeCSVFields) readHeaderLine(path string, offset int) (string, error) {
+	key := fmt.Sprintf("%s#%d", path, offset)
+
+	// os.Stat is only needed for mtime/size invalidation; when
+	// header_cache_ttl is set, freshness is decided by cachedAt alone, so
+	// skip the syscall entirely.
+	var info os.FileInfo
+	var statErr error
+	if f.HeaderCacheTTL <= 0 {
+		info, statErr = os.Stat(path)
+	}
 
-		//read header in file
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() && head.offset > 0 {
-			firstLine = scanner.Text()
-			head.offset--
-			if err := scanner.Err(); err != nil {
-				return errors.Wrapf(err, "error from scanner.Text() in read file")
+	if cachedIf, ok := f.headerLineCache.Load(key); ok {
+		entry := cachedIf.(headerLineCacheEntry)
+		if f.HeaderCacheTTL > 0 {
+			if time.Since(entry.cachedAt) < f.HeaderCacheTTL {
+				return entry.line, nil
 			}
+		} else if statErr == nil && info.ModTime().Equal(entry.modTime) && info.Size() == entry.size {
+			return entry.line, nil
 		}
-		if head.offset != 0 {
-			return errors.Wrapf(err, "error: offset too big")
+	}
+
+	line, err := scanHeaderLine(path, offset)
+	if err != nil {
+		return "", err
+	}
+
+	entry := headerLineCacheEntry{line: line, cachedAt: time.Now()}
+	if statErr == nil && info != nil {
+		entry.modTime = info.ModTime()
+		entry.size = info.Size()
+	}
+	f.headerLineCache.Store(key, entry)
+	return line, nil
+}
+
+// scanHeaderLine opens path and reads the line at offset (1-based, matching
+// the existing csvHeader.offset semantics).
+func scanHeaderLine(path string, offset int) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not open file : log.file.path")
+	}
+	defer file.Close()
+
+	firstLine := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() && offset > 0 {
+		firstLine = scanner.Text()
+		offset--
+		if err := scanner.Err(); err != nil {
+			return "", errors.Wrapf(err, "error from scanner.Text() in read file")
 		}
 	}
-	if text == firstLine {
-		return nil
+	if offset != 0 {
+		return "", errors.New("error: offset too big")
 	}
-	//get header record
-	reader = csv.NewReader(strings.NewReader(firstLine))
-	reader.Comma = f.separator
-	reader.TrimLeadingSpace = f.TrimLeadingSpace
-	// LazyQuotes makes the parser more tolerant to bad string formatting.
-	reader.LazyQuotes = true
-	headcsv, err := reader.Read()
+	return firstLine, nil
+}
+
+// parseHeaderLine splits a header line into column names, memoizing the
+// csv.Reader.Read() call since the same header line is decoded for every
+// event.
+func (f *mydecodeCSVFields) parseHeaderLine(firstLine string) ([]string, error) {
+	if cached, ok := f.headerFieldsCache.Load(firstLine); ok {
+		return cached.([]string), nil
+	}
+	headcsv, err := f.newReader(firstLine).Read()
 	if err != nil {
-		return errors.Wrapf(err, "error decoding first firstLine")
+		return nil, errors.Wrapf(err, "error decoding first firstLine")
 	}
+	f.headerFieldsCache.Store(firstLine, headcsv)
+	return headcsv, nil
+}
 
-	//create json object
-	mymap := make(map[string]string)
-	for i := 0; i < len(headcsv); i++ {
-		mymap[headcsv[i]] = record[i]
+// applyTypes converts the string values of mymap according to f.types,
+// honoring the configured parse_grace mode for conversion failures.
+func (f *mydecodeCSVFields) applyTypes(mymap map[string]string) (map[string]interface{}, error) {
+	typed := make(map[string]interface{}, len(mymap))
+	for name, raw := range mymap {
+		typ, exist := f.types[name]
+		if !exist {
+			typed[name] = raw
+			continue
+		}
+		val, err := convertTypedValue(typ, raw)
+		if err == nil {
+			typed[name] = val
+			continue
+		}
+		switch f.ParseGrace {
+		case parseGraceAutoCast:
+			typed[name] = raw
+		case parseGraceSkipField:
+			// omit the field entirely
+		case parseGraceSkipRow:
+			return nil, errSkipRow
+		default: // parseGraceStop
+			return nil, errors.Wrapf(err, "could not convert field %s to type %s", name, typ)
+		}
 	}
+	return typed, nil
+}
 
-	//put result in fields dest
-	if _, err = event.PutValue(dest, mymap); err != nil {
-		return errors.Wrapf(err, "failed setting field %s", dest)
+// csvFieldName returns the column name a struct field is decoded from, and
+// whether the field should be decoded at all.
+func csvFieldName(field reflect.StructField) (string, bool) {
+	if field.PkgPath != "" {
+		// unexported field
+		return "", false
+	}
+	tag := field.Tag.Get("csv")
+	if tag == "-" {
+		return "", false
+	}
+	if tag == "" {
+		return field.Name, true
+	}
+	return tag, true
+}
+
+// buildStruct decodes mymap into an instance of f.structType honoring its
+// `csv:"column_name"` tags, then returns its exported fields as a nested
+// map the same shape PutValue expects from the untyped path.
+func (f *mydecodeCSVFields) buildStruct(mymap map[string]string) (map[string]interface{}, error) {
+	instance := reflect.New(f.structType).Elem()
+
+	for i := 0; i < f.structType.NumField(); i++ {
+		name, ok := csvFieldName(f.structType.Field(i))
+		if !ok {
+			continue
+		}
+		raw, exist := mymap[name]
+		if !exist {
+			continue
+		}
+		if err := setStructField(instance.Field(i), raw); err != nil {
+			return nil, errors.Wrapf(err, "failed decoding struct field %s", f.structType.Field(i).Name)
+		}
+	}
+
+	out := make(map[string]interface{}, f.structType.NumField())
+	for i := 0; i < f.structType.NumField(); i++ {
+		name, ok := csvFieldName(f.structType.Field(i))
+		if !ok {
+			continue
+		}
+		out[name] = instance.Field(i).Interface()
+	}
+	return out, nil
+}
+
+// setStructField parses raw into fv. A field implementing TypeUnmarshaller
+// takes over its own parsing (e.g. for net.IP, time.Time, url.URL); plain
+// string/int/uint/float/bool kinds are handled directly.
+func setStructField(fv reflect.Value, raw string) error {
+	if fv.CanAddr() {
+		if tu, ok := fv.Addr().Interface().(TypeUnmarshaller); ok {
+			return tu.UnmarshalCSV(raw)
+		}
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(raw)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	default:
+		return errors.Errorf("unsupported struct field type %s (implement TypeUnmarshaller for custom types)", fv.Type())
 	}
 	return nil
 }
 
+// convertTypedValue converts raw into the requested type. Supported types
+// are "int", "float", "bool", "duration" and "timestamp:<layout>", the
+// layout following Go's reference-time format.
+func convertTypedValue(typ, raw string) (interface{}, error) {
+	if strings.HasPrefix(typ, "timestamp:") {
+		layout := strings.TrimPrefix(typ, "timestamp:")
+		return time.Parse(layout, raw)
+	}
+	switch typ {
+	case "int":
+		return strconv.ParseInt(raw, 10, 64)
+	case "float":
+		return strconv.ParseFloat(raw, 64)
+	case "bool":
+		return strconv.ParseBool(raw)
+	case "duration":
+		return time.ParseDuration(raw)
+	default:
+		return nil, errors.Errorf("unknown type %s", typ)
+	}
+}
+
 // String returns a string representation of this processor.
-func (f mydecodeCSVFields) String() string {
+func (f *mydecodeCSVFields) String() string {
 	json, _ := json.Marshal(f.csvConfig)
 	return "decode_csv_field=" + string(json)
 }
@@ -0,0 +1,41 @@
+package mydecode_csv_fields
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// TestDecodeCSVFieldShortRecordDoesNotPanic is a regression test for the
+// index-out-of-range panic that used to happen in the header-mapping loop
+// (mymap[headcsv[i]] = record[i]) when a record had fewer columns than the
+// header and fields_per_record was left unset: applyFieldsPerRecord now
+// derives FieldsPerRecord from the header length, so a short record surfaces
+// as ErrFieldCountMismatch from csv.Reader.Read instead.
+func TestDecodeCSVFieldShortRecordDoesNotPanic(t *testing.T) {
+	c, err := common.NewConfigFrom(map[string]interface{}{
+		"fields": map[string]interface{}{"message": "csv"},
+		"headers": map[string]interface{}{
+			"message": map[string]interface{}{"string": "a,b,c"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed building config: %s", err)
+	}
+	processor, err := MyNewDecodeCSVField(c)
+	if err != nil {
+		t.Fatalf("MyNewDecodeCSVField returned an error: %s", err)
+	}
+
+	event := &beat.Event{Fields: common.MapStr{"message": "x,y"}}
+	_, err = processor.Run(event)
+	if err == nil {
+		t.Fatal("expected an error for a record shorter than the header")
+	}
+	if errors.Cause(err) != ErrFieldCountMismatch {
+		t.Fatalf("got %v, want ErrFieldCountMismatch", err)
+	}
+}
@@ -0,0 +1,73 @@
+package mydecode_csv_fields
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeHeaderFile(t *testing.T, path, line string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(line+"\ndata\n"), 0644); err != nil {
+		t.Fatalf("failed writing %s: %s", path, err)
+	}
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatalf("failed bumping mtime on %s: %s", path, err)
+	}
+}
+
+// TestReadHeaderLineInvalidatesOnMtimeChange covers the default (no
+// header_cache_ttl) path: a changed mtime/size must invalidate the cached
+// header line.
+func TestReadHeaderLineInvalidatesOnMtimeChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.csv")
+	writeHeaderFile(t, path, "a,b,c")
+
+	f := &mydecodeCSVFields{}
+	line, err := f.readHeaderLine(path, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if line != "a,b,c" {
+		t.Fatalf("got %q, want a,b,c", line)
+	}
+
+	writeHeaderFile(t, path, "x,y,z")
+
+	line, err = f.readHeaderLine(path, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if line != "x,y,z" {
+		t.Fatalf("got %q, want cache invalidated to x,y,z after mtime/size change", line)
+	}
+}
+
+// TestReadHeaderLineHonoursCacheTTL covers the header_cache_ttl path: the
+// cached line must survive a file change until the TTL elapses, trading
+// rotation-awareness for skipping the os.Stat call.
+func TestReadHeaderLineHonoursCacheTTL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.csv")
+	writeHeaderFile(t, path, "a,b,c")
+
+	f := &mydecodeCSVFields{csvConfig: csvConfig{HeaderCacheTTL: time.Hour}}
+	line, err := f.readHeaderLine(path, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if line != "a,b,c" {
+		t.Fatalf("got %q, want a,b,c", line)
+	}
+
+	writeHeaderFile(t, path, "x,y,z")
+
+	line, err = f.readHeaderLine(path, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if line != "a,b,c" {
+		t.Fatalf("got %q, want cached line to survive the file change within the TTL", line)
+	}
+}
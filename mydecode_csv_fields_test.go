@@ -0,0 +1,87 @@
+package mydecode_csv_fields
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// TestMyNewDecodeCSVFieldRejectsExpandEventFromField asserts that
+// expand_event_from_field fails construction instead of silently dropping
+// every row but the first through Run, which can only ever return one
+// event (processors.Processor has no multi-event entry point).
+func TestMyNewDecodeCSVFieldRejectsExpandEventFromField(t *testing.T) {
+	c, err := common.NewConfigFrom(map[string]interface{}{
+		"fields":                  map[string]interface{}{"message": "csv"},
+		"multi_record":            true,
+		"expand_event_from_field": "csv",
+	})
+	if err != nil {
+		t.Fatalf("failed building config: %s", err)
+	}
+	if _, err := MyNewDecodeCSVField(c); err == nil {
+		t.Fatal("expected MyNewDecodeCSVField to reject expand_event_from_field")
+	}
+}
+
+// TestRegisterCSVStructRejectsInvalidPrototypes guards against the panic
+// trap buildStruct would otherwise hit on every event: reflect.Type.NumField
+// panics on a non-struct Kind, and reflect.TypeOf(nil) returns a nil Type
+// whose Kind() itself panics.
+func TestRegisterCSVStructRejectsInvalidPrototypes(t *testing.T) {
+	cases := []struct {
+		name      string
+		prototype interface{}
+	}{
+		{"nil prototype", nil},
+		{"non-struct prototype", "not a struct"},
+		{"pointer to non-struct", new(int)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := RegisterCSVStruct("test-"+c.name, c.prototype); err == nil {
+				t.Fatalf("expected RegisterCSVStruct to reject %v", c.prototype)
+			}
+		})
+	}
+}
+
+// TestApplyTypesParseGraceModes exercises each parse_grace mode against a
+// field that fails its configured type conversion.
+func TestApplyTypesParseGraceModes(t *testing.T) {
+	f := &mydecodeCSVFields{types: map[string]string{"n": "int"}}
+	mymap := map[string]string{"n": "not-a-number", "s": "ok"}
+
+	f.ParseGrace = parseGraceSkipField
+	got, err := f.applyTypes(mymap)
+	if err != nil {
+		t.Fatalf("skip_field: unexpected error: %s", err)
+	}
+	if _, exists := got["n"]; exists {
+		t.Fatalf("skip_field: expected field n to be omitted, got %v", got)
+	}
+	if got["s"] != "ok" {
+		t.Fatalf("skip_field: expected untyped field s to pass through, got %v", got)
+	}
+
+	f.ParseGrace = parseGraceAutoCast
+	got, err = f.applyTypes(mymap)
+	if err != nil {
+		t.Fatalf("autoCast: unexpected error: %s", err)
+	}
+	if got["n"] != "not-a-number" {
+		t.Fatalf("autoCast: expected raw value to pass through, got %v", got["n"])
+	}
+
+	f.ParseGrace = parseGraceSkipRow
+	if _, err := f.applyTypes(mymap); errors.Cause(err) != errSkipRow {
+		t.Fatalf("skip_row: expected errSkipRow, got %v", err)
+	}
+
+	f.ParseGrace = parseGraceStop
+	if _, err := f.applyTypes(mymap); err == nil {
+		t.Fatal("stop: expected an error")
+	}
+}
@@ -0,0 +1,113 @@
+package mydecode_csv_fields
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/processors"
+)
+
+type mydecodeLTSVFields struct {
+	ltsvConfig
+	fields map[string]string
+}
+
+type ltsvConfig struct {
+	Fields        common.MapStr `config:"fields"`
+	IgnoreMissing bool          `config:"ignore_missing"`
+	OverwriteKeys bool          `config:"overwrite_keys"`
+	FailOnError   bool          `config:"fail_on_error"`
+}
+
+var defaultLTSVConfig = ltsvConfig{
+	FailOnError: true,
+}
+
+// MyNewDecodeLTSVField constructs a new decode_ltsv_field processor. Unlike
+// mydecode_csv_fields, LTSV (Labeled Tab-Separated Values) is self
+// describing, so there is no header to discover: each line is a sequence of
+// label:value pairs separated by tabs.
+func MyNewDecodeLTSVField(c *common.Config) (processors.Processor, error) {
+	config := defaultLTSVConfig
+
+	err := c.Unpack(&config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack the decode_ltsv_field configuration: %s", err)
+	}
+	if len(config.Fields) == 0 {
+		return nil, errors.New("no fields to decode configured")
+	}
+	f := &mydecodeLTSVFields{ltsvConfig: config}
+	f.fields = make(map[string]string, len(config.Fields))
+	for src, dstIf := range config.Fields.Flatten() {
+		dst, ok := dstIf.(string)
+		if !ok {
+			return nil, errors.Errorf("bad destination mapping for %s: destination field must be string, not %T (got %v)", src, dstIf, dstIf)
+		}
+		f.fields[src] = dst
+	}
+	return f, nil
+}
+
+// Run applies the mydecode_ltsv_field processor to an event.
+func (f *mydecodeLTSVFields) Run(event *beat.Event) (*beat.Event, error) {
+	saved := event
+	if f.FailOnError {
+		saved = cloneEvent(event)
+	}
+	for src, dest := range f.fields {
+		if err := f.decodeLTSVField(src, dest, event); err != nil && f.FailOnError {
+			return saved, err
+		}
+	}
+	return event, nil
+}
+
+func (f *mydecodeLTSVFields) decodeLTSVField(src, dest string, event *beat.Event) error {
+	data, err := event.GetValue(src)
+	if err != nil {
+		if f.IgnoreMissing && errors.Cause(err) == common.ErrKeyNotFound {
+			return nil
+		}
+		return errors.Wrapf(err, "could not fetch value for field %s", src)
+	}
+
+	text, ok := data.(string)
+	if !ok {
+		return errors.Errorf("field %s is not of string type", src)
+	}
+
+	if src != dest && !f.OverwriteKeys {
+		if _, err = event.GetValue(dest); err == nil {
+			return errors.Errorf("target field %s already has a value. Set the overwrite_keys flag or drop/rename the field first", dest)
+		}
+	}
+
+	mymap := make(map[string]string)
+	for _, pair := range strings.Split(text, "\t") {
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, ":", 2)
+		if len(parts) != 2 {
+			return errors.Errorf("malformed LTSV pair %q in field %s", pair, src)
+		}
+		mymap[parts[0]] = parts[1]
+	}
+
+	if _, err = event.PutValue(dest, mymap); err != nil {
+		return errors.Wrapf(err, "failed setting field %s", dest)
+	}
+	return nil
+}
+
+// String returns a string representation of this processor.
+func (f mydecodeLTSVFields) String() string {
+	json, _ := json.Marshal(f.ltsvConfig)
+	return "decode_ltsv_field=" + string(json)
+}
@@ -0,0 +1,34 @@
+package mydecode_csv_fields
+
+import (
+	"testing"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func TestDecodeLTSVField(t *testing.T) {
+	f := &mydecodeLTSVFields{fields: map[string]string{"message": "ltsv"}}
+	event := &beat.Event{Fields: common.MapStr{"message": "host:127.0.0.1\tstatus:200"}}
+
+	if err := f.decodeLTSVField("message", "ltsv", event); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got, ok := event.Fields["ltsv"].(map[string]string)
+	if !ok {
+		t.Fatalf("ltsv field is %T, not map[string]string", event.Fields["ltsv"])
+	}
+	if got["host"] != "127.0.0.1" || got["status"] != "200" {
+		t.Fatalf("got %v, want host=127.0.0.1 status=200", got)
+	}
+}
+
+func TestDecodeLTSVFieldMalformedPair(t *testing.T) {
+	f := &mydecodeLTSVFields{fields: map[string]string{"message": "ltsv"}}
+	event := &beat.Event{Fields: common.MapStr{"message": "no-colon-here"}}
+
+	if err := f.decodeLTSVField("message", "ltsv", event); err == nil {
+		t.Fatal("expected an error for a pair without a colon")
+	}
+}
@@ -0,0 +1,134 @@
+package mydecode_csv_fields
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/pkg/errors"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/processors"
+)
+
+type mydecodeRegexFields struct {
+	regexConfig
+	fields  map[string]string
+	pattern *regexp.Regexp
+}
+
+type regexConfig struct {
+	Fields        common.MapStr `config:"fields"`
+	IgnoreMissing bool          `config:"ignore_missing"`
+	OverwriteKeys bool          `config:"overwrite_keys"`
+	FailOnError   bool          `config:"fail_on_error"`
+	Pattern       string        `config:"pattern"`
+}
+
+var defaultRegexConfig = regexConfig{
+	FailOnError: true,
+}
+
+// MyNewDecodeRegexField constructs a new decode_regex_field processor. The
+// pattern's named capture groups (e.g. Apache/nginx-style access log
+// patterns) become the keys of the resulting map; unnamed groups and the
+// full match are ignored.
+func MyNewDecodeRegexField(c *common.Config) (processors.Processor, error) {
+	config := defaultRegexConfig
+
+	err := c.Unpack(&config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack the decode_regex_field configuration: %s", err)
+	}
+	if len(config.Fields) == 0 {
+		return nil, errors.New("no fields to decode configured")
+	}
+	if config.Pattern == "" {
+		return nil, errors.New("no pattern configured")
+	}
+	pattern, err := regexp.Compile(config.Pattern)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid pattern '%s'", config.Pattern)
+	}
+	hasNamedGroup := false
+	for _, name := range pattern.SubexpNames() {
+		if name != "" {
+			hasNamedGroup = true
+			break
+		}
+	}
+	if !hasNamedGroup {
+		return nil, errors.Errorf("pattern '%s' has no named capture groups", config.Pattern)
+	}
+
+	f := &mydecodeRegexFields{regexConfig: config, pattern: pattern}
+	f.fields = make(map[string]string, len(config.Fields))
+	for src, dstIf := range config.Fields.Flatten() {
+		dst, ok := dstIf.(string)
+		if !ok {
+			return nil, errors.Errorf("bad destination mapping for %s: destination field must be string, not %T (got %v)", src, dstIf, dstIf)
+		}
+		f.fields[src] = dst
+	}
+	return f, nil
+}
+
+// Run applies the mydecode_regex_field processor to an event.
+func (f *mydecodeRegexFields) Run(event *beat.Event) (*beat.Event, error) {
+	saved := event
+	if f.FailOnError {
+		saved = cloneEvent(event)
+	}
+	for src, dest := range f.fields {
+		if err := f.decodeRegexField(src, dest, event); err != nil && f.FailOnError {
+			return saved, err
+		}
+	}
+	return event, nil
+}
+
+func (f *mydecodeRegexFields) decodeRegexField(src, dest string, event *beat.Event) error {
+	data, err := event.GetValue(src)
+	if err != nil {
+		if f.IgnoreMissing && errors.Cause(err) == common.ErrKeyNotFound {
+			return nil
+		}
+		return errors.Wrapf(err, "could not fetch value for field %s", src)
+	}
+
+	text, ok := data.(string)
+	if !ok {
+		return errors.Errorf("field %s is not of string type", src)
+	}
+
+	if src != dest && !f.OverwriteKeys {
+		if _, err = event.GetValue(dest); err == nil {
+			return errors.Errorf("target field %s already has a value. Set the overwrite_keys flag or drop/rename the field first", dest)
+		}
+	}
+
+	match := f.pattern.FindStringSubmatch(text)
+	if match == nil {
+		return errors.Errorf("pattern did not match field %s", src)
+	}
+
+	mymap := make(map[string]string)
+	for i, name := range f.pattern.SubexpNames() {
+		if i == 0 || name == "" {
+			continue
+		}
+		mymap[name] = match[i]
+	}
+
+	if _, err = event.PutValue(dest, mymap); err != nil {
+		return errors.Wrapf(err, "failed setting field %s", dest)
+	}
+	return nil
+}
+
+// String returns a string representation of this processor.
+func (f mydecodeRegexFields) String() string {
+	json, _ := json.Marshal(f.regexConfig)
+	return "decode_regex_field=" + string(json)
+}
@@ -0,0 +1,41 @@
+package mydecode_csv_fields
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func TestDecodeRegexField(t *testing.T) {
+	f := &mydecodeRegexFields{
+		fields:  map[string]string{"message": "parsed"},
+		pattern: regexp.MustCompile(`^(?P<host>\S+) (?P<status>\d+)$`),
+	}
+	event := &beat.Event{Fields: common.MapStr{"message": "127.0.0.1 200"}}
+
+	if err := f.decodeRegexField("message", "parsed", event); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got, ok := event.Fields["parsed"].(map[string]string)
+	if !ok {
+		t.Fatalf("parsed field is %T, not map[string]string", event.Fields["parsed"])
+	}
+	if got["host"] != "127.0.0.1" || got["status"] != "200" {
+		t.Fatalf("got %v, want host=127.0.0.1 status=200", got)
+	}
+}
+
+func TestDecodeRegexFieldNoMatch(t *testing.T) {
+	f := &mydecodeRegexFields{
+		fields:  map[string]string{"message": "parsed"},
+		pattern: regexp.MustCompile(`^(?P<host>\S+) (?P<status>\d+)$`),
+	}
+	event := &beat.Event{Fields: common.MapStr{"message": "not a match"}}
+
+	if err := f.decodeRegexField("message", "parsed", event); err == nil {
+		t.Fatal("expected an error when the pattern does not match")
+	}
+}